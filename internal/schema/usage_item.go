@@ -0,0 +1,142 @@
+package schema
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/tidwall/gjson"
+)
+
+// ValueType identifies the Go type a UsageItem's Value/DefaultValue holds,
+// so callers can read it without a type switch and the YAML/JSON layers
+// know how to coerce a raw value into it.
+type ValueType int
+
+const (
+	Int64 ValueType = iota
+	Float64
+	String
+	StringArray
+	// SubResourceUsage marks an item whose Value/DefaultValue is itself a
+	// nested usage tree (a *ResourceUsage, defined in internal/usage),
+	// e.g. an EKS node group's per-pod request usage.
+	SubResourceUsage
+)
+
+// UsageItem is a single entry in a resource's usage schema: a key a user
+// can set in their usage file (or an estimator can fill in), along with
+// the type its value should be parsed/merged as.
+type UsageItem struct {
+	Key         string
+	ValueType   ValueType
+	Description string
+	// Value is the currently known value, if any, e.g. what a user has
+	// set in their usage file.
+	Value interface{}
+	// DefaultValue is the value to fall back to when Value isn't set,
+	// e.g. the usage schema's documented default.
+	DefaultValue interface{}
+}
+
+// Resource is a Terraform resource as cost estimation sees it: enough to
+// estimate its usage, not its cost breakdown (which lives elsewhere in
+// the real schema package - this subset is only what internal/usage
+// needs).
+type Resource struct {
+	Name         string
+	ResourceType string
+	// UsageSchema lists the usage keys this resource understands, along
+	// with their types and any default values.
+	UsageSchema []*UsageItem
+	// EstimateUsage, if set, fills usage values into sink (keyed the same
+	// way UsageSchema is) using whatever resource-specific logic the
+	// resource needs, e.g. a cloud API call.
+	EstimateUsage func(ctx context.Context, sink map[string]interface{}) error
+}
+
+// Project is a set of resources parsed from a single Terraform
+// project/workspace.
+type Project struct {
+	Resources []*Resource
+}
+
+// UsageData wraps a flat set of parsed attributes - usually a resource's
+// usage map round-tripped through ParseAttributes - so callers can pull
+// typed values out of it the same way they'd read Terraform resource
+// attributes.
+type UsageData struct {
+	Attributes map[string]gjson.Result
+}
+
+// NewUsageData wraps attributes (as produced by ParseAttributes) for the
+// named resource.
+func NewUsageData(name string, attributes map[string]gjson.Result) *UsageData {
+	return &UsageData{Attributes: attributes}
+}
+
+// ParseAttributes flattens v (normally a map[string]interface{} built
+// from a ResourceUsage tree) into gjson results keyed by top-level field,
+// so UsageData can read nested values (objects/arrays) without the
+// caller needing to re-marshal them itself.
+func ParseAttributes(v interface{}) map[string]gjson.Result {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return map[string]gjson.Result{}
+	}
+
+	out := make(map[string]gjson.Result)
+	gjson.ParseBytes(b).ForEach(func(key, value gjson.Result) bool {
+		out[key.String()] = value
+		return true
+	})
+	return out
+}
+
+// Get returns the raw gjson value for key, or the zero Result if key
+// isn't set.
+func (d *UsageData) Get(key string) gjson.Result {
+	if d == nil {
+		return gjson.Result{}
+	}
+	return d.Attributes[key]
+}
+
+func (d *UsageData) GetInt(key string) *int64 {
+	v := d.Get(key)
+	if !v.Exists() || v.Type == gjson.Null {
+		return nil
+	}
+	i := v.Int()
+	return &i
+}
+
+func (d *UsageData) GetFloat(key string) *float64 {
+	v := d.Get(key)
+	if !v.Exists() || v.Type == gjson.Null {
+		return nil
+	}
+	f := v.Float()
+	return &f
+}
+
+func (d *UsageData) GetString(key string) *string {
+	v := d.Get(key)
+	if !v.Exists() || v.Type == gjson.Null {
+		return nil
+	}
+	s := v.String()
+	return &s
+}
+
+func (d *UsageData) GetStringArray(key string) *[]string {
+	v := d.Get(key)
+	if !v.Exists() || v.Type == gjson.Null {
+		return nil
+	}
+
+	arr := make([]string, 0, len(v.Array()))
+	for _, item := range v.Array() {
+		arr = append(arr, item.String())
+	}
+	return &arr
+}
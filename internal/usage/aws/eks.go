@@ -3,11 +3,58 @@ package aws
 
 import (
 	"context"
+	"fmt"
+	"sort"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	asgtypes "github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
 	"github.com/aws/aws-sdk-go-v2/service/eks"
 )
 
+// clusterTagKey returns the ASG tag key EKS and eksctl use to mark an
+// autoscaling group as belonging to a cluster, e.g.
+// "kubernetes.io/cluster/my-cluster".
+func clusterTagKey(clusterName string) string {
+	return "kubernetes.io/cluster/" + clusterName
+}
+
+// Taint mirrors a Kubernetes node taint as reported by a node group's
+// config.
+type Taint struct {
+	Key    string
+	Value  string
+	Effect string
+}
+
+// NodeGroupCapacity describes everything downstream usage estimation
+// needs to size a node group's workload capacity, without a further round
+// of AWS calls: instance types, scaling bounds, capacity purchase type,
+// and the labels/taints that affect pod scheduling.
+//
+// Not wired up yet: nothing in this tree calls
+// EKSDescribeNodeGroupCapacity or EKSListSelfManagedNodeGroups from a
+// resource's EstimateUsage. Turning this richer struct into an actual
+// pod-density/GPU-count/Fargate-vs-EC2 estimate needs an EKS node group
+// resource definition whose EstimateUsage reads the resource's own
+// Terraform config (cluster_name, node_group_name, provider region) to
+// call these helpers with, plus the instance-type-to-vCPU/GPU lookup and
+// Fargate-profile-matching logic to turn NodeGroupCapacity into usage
+// schema values. Neither exists in this chunk - see the doc comment on
+// estimatorBackend in estimator.go for the same gap from the backend
+// registration side.
+type NodeGroupCapacity struct {
+	InstanceTypes    []string
+	DesiredSize      int32
+	MinSize          int32
+	MaxSize          int32
+	CapacityType     string
+	LaunchTemplateID string
+	Labels           map[string]string
+	Taints           []Taint
+	AMIType          string
+}
+
 func eksNewClient(ctx context.Context, region string) (*eks.Client, error) {
 	cfg, err := getConfig(ctx, region)
 	if err != nil {
@@ -16,12 +63,99 @@ func eksNewClient(ctx context.Context, region string) (*eks.Client, error) {
 	return eks.NewFromConfig(cfg), nil
 }
 
+func asgNewClient(ctx context.Context, region string) (*autoscaling.Client, error) {
+	cfg, err := getConfig(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+	return autoscaling.NewFromConfig(cfg), nil
+}
+
+// eksDescribeNodegroupAPI is the subset of eks.Client this file depends
+// on, so tests can substitute a fake implementation.
+type eksDescribeNodegroupAPI interface {
+	DescribeNodegroup(ctx context.Context, params *eks.DescribeNodegroupInput, optFns ...func(*eks.Options)) (*eks.DescribeNodegroupOutput, error)
+	ListNodegroups(ctx context.Context, params *eks.ListNodegroupsInput, optFns ...func(*eks.Options)) (*eks.ListNodegroupsOutput, error)
+}
+
+// asgDescribeTagsAPI is the subset of autoscaling.Client this file
+// depends on, so tests can substitute a fake implementation.
+type asgDescribeTagsAPI interface {
+	DescribeTags(ctx context.Context, params *autoscaling.DescribeTagsInput, optFns ...func(*autoscaling.Options)) (*autoscaling.DescribeTagsOutput, error)
+}
+
+// EKSDescribeNodeGroupCapacity returns the instance types, scaling
+// bounds, capacity purchase type, and scheduling metadata for a managed
+// EKS node group, so callers don't need a further round of AWS calls to
+// size the node group's workload capacity.
+func EKSDescribeNodeGroupCapacity(ctx context.Context, region string, clusterName string, nodeGroupName string) (*NodeGroupCapacity, error) {
+	client, err := eksNewClient(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	return eksDescribeNodeGroupCapacity(ctx, client, clusterName, nodeGroupName)
+}
+
+func eksDescribeNodeGroupCapacity(ctx context.Context, client eksDescribeNodegroupAPI, clusterName string, nodeGroupName string) (*NodeGroupCapacity, error) {
+	result, err := client.DescribeNodegroup(ctx, &eks.DescribeNodegroupInput{
+		ClusterName:   strPtr(clusterName),
+		NodegroupName: strPtr(nodeGroupName),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Nodegroup == nil {
+		return nil, fmt.Errorf("node group %s not found in cluster %s", nodeGroupName, clusterName)
+	}
+
+	ng := result.Nodegroup
+	capacity := &NodeGroupCapacity{
+		InstanceTypes: ng.InstanceTypes,
+		CapacityType:  string(ng.CapacityType),
+		Labels:        ng.Labels,
+		AMIType:       string(ng.AmiType),
+	}
+
+	if ng.ScalingConfig != nil {
+		capacity.DesiredSize = aws.ToInt32(ng.ScalingConfig.DesiredSize)
+		capacity.MinSize = aws.ToInt32(ng.ScalingConfig.MinSize)
+		capacity.MaxSize = aws.ToInt32(ng.ScalingConfig.MaxSize)
+	}
+
+	if ng.LaunchTemplate != nil {
+		capacity.LaunchTemplateID = aws.ToString(ng.LaunchTemplate.Id)
+	}
+
+	for _, taint := range ng.Taints {
+		capacity.Taints = append(capacity.Taints, Taint{
+			Key:    aws.ToString(taint.Key),
+			Value:  aws.ToString(taint.Value),
+			Effect: string(taint.Effect),
+		})
+	}
+
+	return capacity, nil
+}
+
+// EKSGetNodeGroupAutoscalingGroups returns the names of the autoscaling
+// groups backing a managed EKS node group.
+//
+// Deprecated: use EKSDescribeNodeGroupCapacity, which returns the node
+// group's instance types, scaling bounds and scheduling metadata in the
+// same round trip instead of forcing a second one. It does not return the
+// ASG names.
 func EKSGetNodeGroupAutoscalingGroups(ctx context.Context, region string, clusterName string, nodeGroupName string) ([]string, error) {
 	client, err := eksNewClient(ctx, region)
 	if err != nil {
 		return []string{}, err
 	}
 
+	return eksGetNodeGroupAutoscalingGroups(ctx, client, clusterName, nodeGroupName)
+}
+
+func eksGetNodeGroupAutoscalingGroups(ctx context.Context, client eksDescribeNodegroupAPI, clusterName string, nodeGroupName string) ([]string, error) {
 	result, err := client.DescribeNodegroup(ctx, &eks.DescribeNodegroupInput{
 		ClusterName:   strPtr(clusterName),
 		NodegroupName: strPtr(nodeGroupName),
@@ -30,6 +164,10 @@ func EKSGetNodeGroupAutoscalingGroups(ctx context.Context, region string, cluste
 		return []string{}, err
 	}
 
+	if result.Nodegroup == nil || result.Nodegroup.Resources == nil {
+		return []string{}, fmt.Errorf("node group %s not found in cluster %s", nodeGroupName, clusterName)
+	}
+
 	asgNames := make([]string, 0, len(result.Nodegroup.Resources.AutoScalingGroups))
 	for _, asg := range result.Nodegroup.Resources.AutoScalingGroups {
 		asgNames = append(asgNames, aws.ToString(asg.Name))
@@ -37,3 +175,127 @@ func EKSGetNodeGroupAutoscalingGroups(ctx context.Context, region string, cluste
 
 	return asgNames, nil
 }
+
+// EKSListSelfManagedNodeGroups discovers self-managed node groups for a
+// cluster by scanning for autoscaling groups tagged
+// kubernetes.io/cluster/<clusterName>, then excluding any ASG that's
+// already accounted for by a managed node group. Managed node groups only
+// cover the EKS-managed case, so this is the only way to find capacity
+// added via a plain aws_autoscaling_group resource pointed at the
+// cluster.
+func EKSListSelfManagedNodeGroups(ctx context.Context, region string, clusterName string) ([]string, error) {
+	eksClient, err := eksNewClient(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	asgClient, err := asgNewClient(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	return eksListSelfManagedNodeGroups(ctx, eksClient, asgClient, clusterName)
+}
+
+func eksListSelfManagedNodeGroups(ctx context.Context, eksClient eksDescribeNodegroupAPI, asgClient asgDescribeTagsAPI, clusterName string) ([]string, error) {
+	clusterASGs, err := asgNamesTaggedForCluster(ctx, asgClient, clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	managedASGs, err := managedNodeGroupASGNames(ctx, eksClient, clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	selfManaged := make([]string, 0, len(clusterASGs))
+	for _, asgName := range clusterASGs {
+		if !managedASGs[asgName] {
+			selfManaged = append(selfManaged, asgName)
+		}
+	}
+
+	sort.Strings(selfManaged)
+
+	return selfManaged, nil
+}
+
+// asgNamesTaggedForCluster returns the names of every autoscaling group
+// tagged kubernetes.io/cluster/<clusterName>, paginating through
+// DescribeTags as needed.
+func asgNamesTaggedForCluster(ctx context.Context, client asgDescribeTagsAPI, clusterName string) ([]string, error) {
+	tagKey := clusterTagKey(clusterName)
+
+	var asgNames []string
+	var nextToken *string
+
+	for {
+		result, err := client.DescribeTags(ctx, &autoscaling.DescribeTagsInput{
+			Filters: []asgtypes.Filter{
+				{
+					Name:   strPtr("key"),
+					Values: []string{tagKey},
+				},
+			},
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tag := range result.Tags {
+			if aws.ToString(tag.ResourceType) == "auto-scaling-group" {
+				asgNames = append(asgNames, aws.ToString(tag.ResourceId))
+			}
+		}
+
+		if result.NextToken == nil {
+			break
+		}
+		nextToken = result.NextToken
+	}
+
+	return asgNames, nil
+}
+
+// managedNodeGroupASGNames returns the set of ASG names already backing
+// one of the cluster's managed node groups.
+func managedNodeGroupASGNames(ctx context.Context, client eksDescribeNodegroupAPI, clusterName string) (map[string]bool, error) {
+	managed := make(map[string]bool)
+
+	var nextToken *string
+	for {
+		listResult, err := client.ListNodegroups(ctx, &eks.ListNodegroupsInput{
+			ClusterName: strPtr(clusterName),
+			NextToken:   nextToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, nodeGroupName := range listResult.Nodegroups {
+			describeResult, err := client.DescribeNodegroup(ctx, &eks.DescribeNodegroupInput{
+				ClusterName:   strPtr(clusterName),
+				NodegroupName: strPtr(nodeGroupName),
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			if describeResult.Nodegroup == nil || describeResult.Nodegroup.Resources == nil {
+				continue
+			}
+
+			for _, asg := range describeResult.Nodegroup.Resources.AutoScalingGroups {
+				managed[aws.ToString(asg.Name)] = true
+			}
+		}
+
+		if listResult.NextToken == nil {
+			break
+		}
+		nextToken = listResult.NextToken
+	}
+
+	return managed, nil
+}
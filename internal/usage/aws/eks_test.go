@@ -0,0 +1,223 @@
+package aws
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	asgtypes "github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeEKSClient struct {
+	nodegroups       []string
+	describeByName   map[string]*ekstypes.Nodegroup
+	describeErr      error
+	listNodegroupErr error
+}
+
+func (f *fakeEKSClient) DescribeNodegroup(_ context.Context, params *eks.DescribeNodegroupInput, _ ...func(*eks.Options)) (*eks.DescribeNodegroupOutput, error) {
+	if f.describeErr != nil {
+		return nil, f.describeErr
+	}
+
+	ng, ok := f.describeByName[aws.ToString(params.NodegroupName)]
+	if !ok {
+		return &eks.DescribeNodegroupOutput{}, nil
+	}
+
+	return &eks.DescribeNodegroupOutput{Nodegroup: ng}, nil
+}
+
+func (f *fakeEKSClient) ListNodegroups(_ context.Context, _ *eks.ListNodegroupsInput, _ ...func(*eks.Options)) (*eks.ListNodegroupsOutput, error) {
+	if f.listNodegroupErr != nil {
+		return nil, f.listNodegroupErr
+	}
+
+	return &eks.ListNodegroupsOutput{Nodegroups: f.nodegroups}, nil
+}
+
+type fakeASGClient struct {
+	tags   []asgtypes.TagDescription
+	tagErr error
+}
+
+func (f *fakeASGClient) DescribeTags(_ context.Context, _ *autoscaling.DescribeTagsInput, _ ...func(*autoscaling.Options)) (*autoscaling.DescribeTagsOutput, error) {
+	if f.tagErr != nil {
+		return nil, f.tagErr
+	}
+
+	return &autoscaling.DescribeTagsOutput{Tags: f.tags}, nil
+}
+
+func strPtrT(s string) *string { return &s }
+
+func TestEKSDescribeNodeGroupCapacity(t *testing.T) {
+	client := &fakeEKSClient{
+		describeByName: map[string]*ekstypes.Nodegroup{
+			"ng-1": {
+				InstanceTypes: []string{"t3.medium", "t3.large"},
+				CapacityType:  ekstypes.CapacityTypesSpot,
+				AmiType:       ekstypes.AMITypesAl2X8664,
+				Labels:        map[string]string{"env": "prod"},
+				ScalingConfig: &ekstypes.NodegroupScalingConfig{
+					DesiredSize: int32Ptr(3),
+					MinSize:     int32Ptr(1),
+					MaxSize:     int32Ptr(5),
+				},
+				LaunchTemplate: &ekstypes.LaunchTemplateSpecification{
+					Id: strPtrT("lt-0123456789"),
+				},
+				Taints: []ekstypes.Taint{
+					{Key: strPtrT("dedicated"), Value: strPtrT("gpu"), Effect: ekstypes.TaintEffectNoSchedule},
+				},
+			},
+		},
+	}
+
+	capacity, err := eksDescribeNodeGroupCapacity(context.Background(), client, "my-cluster", "ng-1")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"t3.medium", "t3.large"}, capacity.InstanceTypes)
+	assert.Equal(t, string(ekstypes.CapacityTypesSpot), capacity.CapacityType)
+	assert.Equal(t, string(ekstypes.AMITypesAl2X8664), capacity.AMIType)
+	assert.Equal(t, int32(3), capacity.DesiredSize)
+	assert.Equal(t, int32(1), capacity.MinSize)
+	assert.Equal(t, int32(5), capacity.MaxSize)
+	assert.Equal(t, "lt-0123456789", capacity.LaunchTemplateID)
+	assert.Equal(t, map[string]string{"env": "prod"}, capacity.Labels)
+	assert.Equal(t, []Taint{{Key: "dedicated", Value: "gpu", Effect: string(ekstypes.TaintEffectNoSchedule)}}, capacity.Taints)
+}
+
+func TestEKSDescribeNodeGroupCapacity_Error(t *testing.T) {
+	client := &fakeEKSClient{describeErr: assert.AnError}
+
+	_, err := eksDescribeNodeGroupCapacity(context.Background(), client, "my-cluster", "ng-1")
+	assert.Error(t, err)
+}
+
+func TestEKSDescribeNodeGroupCapacity_NotFound(t *testing.T) {
+	client := &fakeEKSClient{describeByName: map[string]*ekstypes.Nodegroup{}}
+
+	_, err := eksDescribeNodeGroupCapacity(context.Background(), client, "my-cluster", "missing-ng")
+	assert.Error(t, err)
+}
+
+func TestEKSGetNodeGroupAutoscalingGroups(t *testing.T) {
+	client := &fakeEKSClient{
+		describeByName: map[string]*ekstypes.Nodegroup{
+			"ng-1": {
+				Resources: &ekstypes.NodegroupResources{
+					AutoScalingGroups: []ekstypes.AutoScalingGroup{{Name: strPtrT("managed-asg")}},
+				},
+			},
+		},
+	}
+
+	names, err := eksGetNodeGroupAutoscalingGroups(context.Background(), client, "my-cluster", "ng-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"managed-asg"}, names)
+}
+
+func TestEKSGetNodeGroupAutoscalingGroups_NotFound(t *testing.T) {
+	client := &fakeEKSClient{describeByName: map[string]*ekstypes.Nodegroup{}}
+
+	_, err := eksGetNodeGroupAutoscalingGroups(context.Background(), client, "my-cluster", "missing-ng")
+	assert.Error(t, err)
+}
+
+func TestEKSGetNodeGroupAutoscalingGroups_NilResources(t *testing.T) {
+	client := &fakeEKSClient{
+		describeByName: map[string]*ekstypes.Nodegroup{"ng-1": {}},
+	}
+
+	_, err := eksGetNodeGroupAutoscalingGroups(context.Background(), client, "my-cluster", "ng-1")
+	assert.Error(t, err)
+}
+
+func TestEKSListSelfManagedNodeGroups(t *testing.T) {
+	tests := []struct {
+		name      string
+		eksClient *fakeEKSClient
+		asgClient *fakeASGClient
+		want      []string
+		wantErr   bool
+	}{
+		{
+			name: "excludes ASGs already owned by a managed node group",
+			eksClient: &fakeEKSClient{
+				nodegroups: []string{"ng-1"},
+				describeByName: map[string]*ekstypes.Nodegroup{
+					"ng-1": {
+						Resources: &ekstypes.NodegroupResources{
+							AutoScalingGroups: []ekstypes.AutoScalingGroup{{Name: strPtrT("managed-asg")}},
+						},
+					},
+				},
+			},
+			asgClient: &fakeASGClient{
+				tags: []asgtypes.TagDescription{
+					{ResourceId: strPtrT("managed-asg"), ResourceType: strPtrT("auto-scaling-group")},
+					{ResourceId: strPtrT("self-managed-asg"), ResourceType: strPtrT("auto-scaling-group")},
+				},
+			},
+			want: []string{"self-managed-asg"},
+		},
+		{
+			name: "no managed node groups",
+			eksClient: &fakeEKSClient{
+				describeByName: map[string]*ekstypes.Nodegroup{},
+			},
+			asgClient: &fakeASGClient{
+				tags: []asgtypes.TagDescription{
+					{ResourceId: strPtrT("self-managed-a"), ResourceType: strPtrT("auto-scaling-group")},
+					{ResourceId: strPtrT("self-managed-b"), ResourceType: strPtrT("auto-scaling-group")},
+				},
+			},
+			want: []string{"self-managed-a", "self-managed-b"},
+		},
+		{
+			name: "ignores non-ASG tagged resources",
+			eksClient: &fakeEKSClient{
+				describeByName: map[string]*ekstypes.Nodegroup{},
+			},
+			asgClient: &fakeASGClient{
+				tags: []asgtypes.TagDescription{
+					{ResourceId: strPtrT("self-managed-a"), ResourceType: strPtrT("auto-scaling-group")},
+					{ResourceId: strPtrT("some-other-resource"), ResourceType: strPtrT("launch-configuration")},
+				},
+			},
+			want: []string{"self-managed-a"},
+		},
+		{
+			name: "returns error from tag lookup",
+			eksClient: &fakeEKSClient{
+				describeByName: map[string]*ekstypes.Nodegroup{},
+			},
+			asgClient: &fakeASGClient{tagErr: assert.AnError},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := eksListSelfManagedNodeGroups(context.Background(), tt.eksClient, tt.asgClient, "my-cluster")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func int32Ptr(i int32) *int32 { return &i }
@@ -0,0 +1,62 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/infracost/infracost/internal/schema"
+	"github.com/infracost/infracost/internal/usage"
+)
+
+// estimatorBackend adapts this package's EKS/ASG helpers to the
+// usage.EstimatorBackend interface, so they can be toggled with
+// --estimator like any other backend instead of always running, and so
+// the aws-sdk-go-v2 dependency is only pulled in by code that imports
+// this package.
+//
+// Partial delivery: the request this backend was built for asked for
+// built-in CloudWatch, EKS and ASG backends. Only the EKS/ASG side exists
+// here, and even that is not wired up yet - see Estimate below. No
+// CloudWatch backend exists in this package at all; estimating from
+// CloudWatch metrics (e.g. an ASG's actual instance count, or a
+// cluster's node CPU/memory utilisation) is unstarted.
+//
+// It is not registered with usage.RegisterEstimator: Estimate is still a
+// no-op. EKSDescribeNodeGroupCapacity/EKSListSelfManagedNodeGroups in
+// eks.go both need a region, cluster name and (for the node-group case) a
+// node group name, and the only thing Estimate gets to work with is
+// resource.Name (the Terraform address, e.g.
+// "aws_eks_node_group.example") and resource.ResourceType - neither
+// carries the resource's own attributes. Deriving those three values
+// requires reading the resource's Terraform configuration
+// (cluster_name/node_group_name/provider region), and schema.Resource as
+// it exists in this tree has no such attribute-access layer - unlike
+// usage.UsageData, which reads merged *usage* values, not the resource's
+// HCL config. Adding one is a prerequisite for this backend to do
+// anything; faking the lookup here would make Supports() claim a
+// resource type that silently estimates nothing, which is worse than
+// leaving it unregistered. Registering a backend whose Supports() claims
+// a resource type but whose Estimate() does nothing would also make
+// syncResourceUsages count that resource as having a live backend -
+// inflating EstimationCount - without actually estimating anything.
+var _ usage.EstimatorBackend = estimatorBackend{}
+
+type estimatorBackend struct{}
+
+func (estimatorBackend) Name() string { return "aws" }
+
+func (estimatorBackend) Supports(resourceType string) bool {
+	switch resourceType {
+	case "aws_eks_node_group", "aws_autoscaling_group":
+		return true
+	default:
+		return false
+	}
+}
+
+func (estimatorBackend) Estimate(ctx context.Context, resource *schema.Resource, sink map[string]interface{}) error {
+	// TODO: wire this up to eksDescribeNodeGroupCapacity/
+	// eksListSelfManagedNodeGroups and register it with
+	// usage.RegisterEstimator once it actually estimates something - see
+	// the doc comment on estimatorBackend above for what's blocking that.
+	return nil
+}
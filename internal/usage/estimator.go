@@ -0,0 +1,59 @@
+package usage
+
+import (
+	"context"
+
+	"github.com/infracost/infracost/internal/schema"
+)
+
+// EstimatorBackend lets a usage source - a cloud SDK, a metrics backend
+// like Prometheus or Datadog, a cost-and-usage-report reader, or anything
+// else - contribute estimated usage values for a resource during sync.
+// Backends are consulted independently of resource.EstimateUsage, so
+// users can plug in their own metrics source without forking infracost,
+// and the core usage package doesn't need to depend on any one cloud SDK.
+type EstimatorBackend interface {
+	// Name identifies the backend for the --estimator flag and log output.
+	Name() string
+	// Supports reports whether this backend can estimate usage for the
+	// given Terraform resource type, e.g. "aws_eks_node_group".
+	Supports(resourceType string) bool
+	// Estimate writes estimated values into sink, keyed the same way
+	// resource.EstimateUsage always has (by usage schema key).
+	Estimate(ctx context.Context, resource *schema.Resource, sink map[string]interface{}) error
+}
+
+// estimatorRegistry is the process-wide list of registered backends.
+// Backends are added to it with RegisterEstimator; this package imports
+// none of them itself, so it doesn't hard-depend on every possible
+// backend.
+var estimatorRegistry []EstimatorBackend
+
+// RegisterEstimator adds b to the registry. Backends are consulted in
+// registration order, so a backend registered later can layer its values
+// on top of one registered earlier for the same resource type.
+func RegisterEstimator(b EstimatorBackend) {
+	estimatorRegistry = append(estimatorRegistry, b)
+}
+
+// estimatorsFor returns the registered backends that support
+// resourceType, in registration order. If enabled is non-nil, only
+// backends whose Name() is present (and true) in enabled are returned,
+// which is how the --estimator flag disables individual backends.
+func estimatorsFor(resourceType string, enabled map[string]bool) []EstimatorBackend {
+	backends := make([]EstimatorBackend, 0, len(estimatorRegistry))
+
+	for _, b := range estimatorRegistry {
+		if !b.Supports(resourceType) {
+			continue
+		}
+
+		if enabled != nil && !enabled[b.Name()] {
+			continue
+		}
+
+		backends = append(backends, b)
+	}
+
+	return backends
+}
@@ -0,0 +1,112 @@
+package usage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// overrideSuffix is the suffix (before the file extension) that marks a
+// usage file as an auto-detected override of a base file, e.g.
+// infracost-usage.yml -> infracost-usage.override.yml. This is analogous
+// to Terraform's *_override.tf convention.
+const overrideSuffix = ".override"
+
+// LoadAndMerge reads each usage file in paths in order and layers them on
+// top of each other, with later paths overriding earlier ones for any
+// value they both define. This gives --usage-file the same semantics as
+// layering multiple docker-compose -f files: the first path is the base
+// and every subsequent path can add to or override it.
+//
+// Before moving on to the next explicit path, LoadAndMerge also checks for
+// an override file auto-detected next to the current one (e.g.
+// infracost-usage.yml + infracost-usage.override.yml) and, if present,
+// merges it in immediately. Sub-resource merges are deep, so an override
+// only needs to specify the keys it actually wants to change.
+func LoadAndMerge(paths []string) (*UsageFile, error) {
+	merged := &UsageFile{}
+
+	for _, path := range paths {
+		if err := mergeUsageFileFrom(merged, path); err != nil {
+			return nil, err
+		}
+
+		overridePath := overridePathFor(path)
+		if overridePath == "" {
+			continue
+		}
+
+		if _, err := os.Stat(overridePath); err != nil {
+			continue
+		}
+
+		log.Debugf("found usage override file %s for %s", overridePath, path)
+		if err := mergeUsageFileFrom(merged, overridePath); err != nil {
+			return nil, err
+		}
+	}
+
+	return merged, nil
+}
+
+// mergeUsageFileFrom loads the usage file at path and merges it into dest,
+// recording path against every value it contributes so sync errors can
+// point back at the originating file.
+func mergeUsageFileFrom(dest *UsageFile, path string) error {
+	usageFile, err := LoadUsageFile(path)
+	if err != nil {
+		return fmt.Errorf("error loading usage file %s: %w", path, err)
+	}
+
+	destUsagesMap := resourceUsagesMap(dest.ResourceUsages)
+
+	for _, resourceUsage := range usageFile.ResourceUsages {
+		destResourceUsage, ok := destUsagesMap[resourceUsage.Name]
+		if !ok {
+			destResourceUsage = &ResourceUsage{Name: resourceUsage.Name}
+			dest.ResourceUsages = append(dest.ResourceUsages, destResourceUsage)
+			destUsagesMap[resourceUsage.Name] = destResourceUsage
+		}
+
+		mergeResourceUsages(destResourceUsage, resourceUsage, MergeResourceUsagesOpts{})
+		recordSourceFiles(destResourceUsage, resourceUsage, path)
+	}
+
+	return nil
+}
+
+// recordSourceFiles walks the items that src just contributed to dest and
+// notes path as their origin, so a later validation or sync error can name
+// "infracost-usage.override.yml" instead of just naming the merged value.
+// Only the path is recorded, not a line number.
+func recordSourceFiles(dest *ResourceUsage, src *ResourceUsage, path string) {
+	if dest.SourceFiles == nil {
+		dest.SourceFiles = make(map[string]string, len(src.Items))
+	}
+
+	for _, srcItem := range src.Items {
+		if srcItem.Value == nil {
+			continue
+		}
+
+		dest.SourceFiles[srcItem.Key] = path
+	}
+}
+
+// overridePathFor returns the auto-detected override path for a base usage
+// file path, e.g. infracost-usage.yml -> infracost-usage.override.yml. It
+// returns an empty string for a path that is already an override file, so
+// we don't go looking for an override of an override.
+func overridePathFor(path string) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+
+	if strings.HasSuffix(base, overrideSuffix) {
+		return ""
+	}
+
+	return base + overrideSuffix + ext
+}
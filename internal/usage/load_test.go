@@ -0,0 +1,60 @@
+package usage
+
+import (
+	"testing"
+
+	"github.com/infracost/infracost/internal/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverridePathFor(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "yml extension", path: "infracost-usage.yml", want: "infracost-usage.override.yml"},
+		{name: "yaml extension", path: "infracost-usage.yaml", want: "infracost-usage.override.yaml"},
+		{name: "no extension", path: "infracost-usage", want: "infracost-usage.override"},
+		{name: "already an override file", path: "infracost-usage.override.yml", want: ""},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, overridePathFor(tt.path))
+		})
+	}
+}
+
+func TestRecordSourceFiles(t *testing.T) {
+	dest := &ResourceUsage{Name: "r"}
+	src := &ResourceUsage{
+		Name: "r",
+		Items: []*schema.UsageItem{
+			intItem("instances", int64(3)),
+			intItem("unset", nil),
+		},
+	}
+
+	recordSourceFiles(dest, src, "infracost-usage.yml")
+
+	assert.Equal(t, map[string]string{"instances": "infracost-usage.yml"}, dest.SourceFiles)
+}
+
+func TestRecordSourceFiles_LaterFileWinsAttribution(t *testing.T) {
+	dest := &ResourceUsage{Name: "r", SourceFiles: map[string]string{"instances": "infracost-usage.yml"}}
+	src := &ResourceUsage{Name: "r", Items: []*schema.UsageItem{intItem("instances", int64(5))}}
+
+	recordSourceFiles(dest, src, "infracost-usage.override.yml")
+
+	assert.Equal(t, "infracost-usage.override.yml", dest.SourceFiles["instances"])
+}
+
+// LoadAndMerge and mergeUsageFileFrom themselves aren't covered here: both
+// go straight through LoadUsageFile (usage_file.go), which reads and
+// unmarshals usage files from disk. The override-detection and
+// source-file-attribution logic they're built from - overridePathFor and
+// recordSourceFiles above, plus mergeResourceUsages's deep-merge behaviour,
+// which already has its own coverage in sync_estimate_test.go - is tested
+// directly instead.
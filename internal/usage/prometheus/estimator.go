@@ -0,0 +1,136 @@
+// Package prometheus implements a usage.EstimatorBackend that estimates
+// usage values by running PromQL queries against a Prometheus-compatible
+// HTTP API, configured per Terraform resource type by the caller of New.
+// This lets users point infracost at their own metrics stack (Prometheus,
+// Thanos, Cortex, Mimir, ...) instead of relying on cloud-provider SDK
+// calls for usage estimation.
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/infracost/infracost/internal/schema"
+)
+
+// Query maps a usage schema key to the PromQL expression that estimates
+// it, e.g. {Key: "instances", Expr: `count(up{job="$resource_name"})`}.
+// $resource_name is substituted with the Terraform resource's name before
+// the query is run.
+type Query struct {
+	Key  string
+	Expr string
+}
+
+// Backend is a usage.EstimatorBackend backed by a Prometheus-compatible
+// HTTP API. Queries are configured per Terraform resource type and passed
+// in to New; nothing in infracost's usage packages reads them from the
+// reference file.
+type Backend struct {
+	// Address is the base URL of the Prometheus HTTP API, e.g.
+	// http://localhost:9090.
+	Address string
+	// Queries maps a Terraform resource type, e.g. "aws_autoscaling_group",
+	// to the PromQL queries that estimate its usage schema.
+	Queries map[string][]Query
+
+	client *http.Client
+}
+
+// New returns a Backend that queries the Prometheus-compatible API at
+// address using the given per-resource-type queries.
+func New(address string, queries map[string][]Query) *Backend {
+	return &Backend{
+		Address: address,
+		Queries: queries,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (b *Backend) Name() string { return "prometheus" }
+
+func (b *Backend) Supports(resourceType string) bool {
+	_, ok := b.Queries[resourceType]
+	return ok
+}
+
+func (b *Backend) Estimate(ctx context.Context, resource *schema.Resource, sink map[string]interface{}) error {
+	queries, ok := b.Queries[resource.ResourceType]
+	if !ok {
+		return nil
+	}
+
+	for _, q := range queries {
+		expr := strings.ReplaceAll(q.Expr, "$resource_name", resource.Name)
+
+		val, err := b.instantQuery(ctx, expr)
+		if err != nil {
+			return fmt.Errorf("prometheus query for %s failed: %w", q.Key, err)
+		}
+
+		if val != nil {
+			sink[q.Key] = *val
+		}
+	}
+
+	return nil
+}
+
+// instantQuery runs expr as an instant query and returns the scalar value
+// of its first result series, or nil if the query returned no results.
+func (b *Backend) instantQuery(ctx context.Context, expr string) (*float64, error) {
+	endpoint := fmt.Sprintf("%s/api/v1/query?query=%s", b.Address, url.QueryEscape(expr))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("prometheus query %q returned status %s", expr, resp.Status)
+	}
+
+	var parsed struct {
+		Status string `json:"status"`
+		Data   struct {
+			Result []struct {
+				Value []interface{} `json:"value"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, err
+	}
+
+	if parsed.Status != "success" || len(parsed.Data.Result) == 0 {
+		return nil, nil
+	}
+
+	if len(parsed.Data.Result[0].Value) < 2 {
+		return nil, fmt.Errorf("prometheus response for query %q has no value in its result", expr)
+	}
+
+	raw, ok := parsed.Data.Result[0].Value[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected value type in prometheus response for query %q", expr)
+	}
+
+	val, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected value %q in prometheus response for query %q: %w", raw, expr, err)
+	}
+
+	return &val, nil
+}
@@ -0,0 +1,102 @@
+package prometheus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/infracost/infracost/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackend_Estimate(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		wantErr    bool
+		wantVal    float64
+		wantNoVal  bool
+	}{
+		{
+			name:       "successful query",
+			statusCode: http.StatusOK,
+			body:       `{"status":"success","data":{"result":[{"value":[1700000000,"5"]}]}}`,
+			wantVal:    5,
+		},
+		{
+			name:       "no results",
+			statusCode: http.StatusOK,
+			body:       `{"status":"success","data":{"result":[]}}`,
+			wantNoVal:  true,
+		},
+		{
+			name:       "non-2xx status",
+			statusCode: http.StatusInternalServerError,
+			body:       `{"status":"error"}`,
+			wantErr:    true,
+		},
+		{
+			name:       "result value missing the sample",
+			statusCode: http.StatusOK,
+			body:       `{"status":"success","data":{"result":[{"value":[1700000000]}]}}`,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			backend := New(server.URL, map[string][]Query{
+				"aws_autoscaling_group": {{Key: "instances", Expr: `count(up{job="$resource_name"})`}},
+			})
+
+			sink := map[string]interface{}{}
+			resource := &schema.Resource{Name: "asg", ResourceType: "aws_autoscaling_group"}
+			err := backend.Estimate(context.Background(), resource, sink)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			if tt.wantNoVal {
+				assert.NotContains(t, sink, "instances")
+				return
+			}
+
+			assert.Equal(t, tt.wantVal, sink["instances"])
+		})
+	}
+}
+
+func TestBackend_Estimate_UnsupportedResourceType(t *testing.T) {
+	backend := New("http://unused", map[string][]Query{
+		"aws_autoscaling_group": {{Key: "instances", Expr: "up"}},
+	})
+
+	sink := map[string]interface{}{}
+	resource := &schema.Resource{Name: "bucket", ResourceType: "aws_s3_bucket"}
+	err := backend.Estimate(context.Background(), resource, sink)
+
+	require.NoError(t, err)
+	assert.Empty(t, sink)
+}
+
+func TestBackend_Supports(t *testing.T) {
+	backend := New("http://unused", map[string][]Query{
+		"aws_autoscaling_group": {{Key: "instances", Expr: "up"}},
+	})
+
+	assert.True(t, backend.Supports("aws_autoscaling_group"))
+	assert.False(t, backend.Supports("aws_s3_bucket"))
+}
@@ -3,6 +3,7 @@ package usage
 import (
 	"context"
 	"sort"
+	"strings"
 
 	"github.com/infracost/infracost/internal/schema"
 	log "github.com/sirupsen/logrus"
@@ -13,13 +14,97 @@ type SyncResult struct {
 	ResourceCount    int
 	EstimationCount  int
 	EstimationErrors map[string]error
+	Conflicts        map[string]UsageConflict
+	// EstimationApplied and EstimationSkipped count items across all
+	// resources: applied is values the estimator was allowed to write,
+	// skipped is values apply-once mode left alone because the user had
+	// already set or previously accepted them.
+	EstimationApplied int
+	EstimationSkipped int
+}
+
+// UsageConflict is recorded when a three-way merge finds that the
+// user-edited value in the usage file and the newly computed value from
+// the reference/estimator have both changed since the last sync, and
+// neither can be taken without possibly discarding the other's intent.
+// The user's value is kept; NewValue is surfaced so the CLI can tell the
+// user what they're not getting automatically.
+//
+// Orphaned marks a different case: Key is no longer part of the
+// candidate at all (the reference file/resource's usage schema dropped
+// it, or the user hand-added a key that was never part of either), so
+// there's no NewValue to compare against - the existing value is just
+// carried forward as-is and flagged so the CLI can tell the user it's no
+// longer a recognised usage key.
+type UsageConflict struct {
+	ResourceName string
+	Key          string
+	UserValue    interface{}
+	NewValue     interface{}
+	Orphaned     bool
+	// SourceFile is the usage file UserValue was last loaded from, per
+	// ResourceUsage.SourceFiles, so the CLI can point the user at the
+	// file their kept value actually came from instead of just naming
+	// the resource. Empty if LoadAndMerge never recorded one for this
+	// key (e.g. it only existed in the last-synced snapshot).
+	SourceFile string
 }
 
 type MergeResourceUsagesOpts struct {
 	OverrideValueType bool
 }
 
-func SyncUsageData(usageFile *UsageFile, projects []*schema.Project) (*SyncResult, error) {
+// SyncMode controls whether, and how aggressively, estimated usage values
+// are allowed to overwrite what's already in the usage file. It mirrors
+// the ApplyOnce/Reconcile distinction used by ClusterResourceSet for
+// applying discovered config.
+type SyncMode string
+
+const (
+	// SyncModeApplyOnce only writes an estimated value the first time a
+	// destination item has no user value and wasn't previously produced by
+	// the estimator itself, so hand-tuned values are never stomped on a
+	// later sync.
+	SyncModeApplyOnce SyncMode = "apply-once"
+	// SyncModeReconcile always writes the latest estimated value into the
+	// sync candidate. The three-way merge against the usage file that
+	// follows in syncResourceUsages still keeps a usage file value that
+	// differs from the last-synced snapshot and reports it as a conflict,
+	// so an estimated value does not replace such a value.
+	SyncModeReconcile SyncMode = "reconcile"
+	// SyncModeOff skips resource.EstimateUsage entirely.
+	SyncModeOff SyncMode = "off"
+)
+
+// lastSyncedKey is the hidden top-level key under which we store a
+// snapshot of the resource usage tree as it was written on the last
+// successful sync, similar to how kubectl stores
+// kubectl.kubernetes.io/last-applied-configuration. It lets us tell apart
+// "the user changed this since last sync" from "the schema/estimator
+// changed this since last sync" on the next run.
+//
+// NOTE: this package only carries LastSynced and EstimatedKeys as in-memory
+// UsageFile fields; syncResourceUsages populates them on every sync, but
+// nothing here marshals them under this key (or reads them back) in the
+// on-disk usage file. LoadUsageFile in usage_file.go reads only the
+// resource_usage key, and this package has no code that writes a usage
+// file, so the snapshot does not survive a sync -> save -> load -> sync
+// round trip.
+const lastSyncedKey = "_infracost_last_synced"
+
+// SyncUsageData syncs usageFile against the given projects' resources,
+// estimating usage values where the resources support it. syncMode
+// controls whether estimated values are allowed to overwrite what's
+// already in the usage file; an empty syncMode defaults to
+// SyncModeApplyOnce so a plain sync never silently discards a user's
+// hand-tuned value. enabledEstimators is the set of backend names allowed
+// to run, as chosen by --estimator; a nil map runs every registered
+// backend.
+func SyncUsageData(usageFile *UsageFile, projects []*schema.Project, syncMode SyncMode, enabledEstimators map[string]bool) (*SyncResult, error) {
+	if syncMode == "" {
+		syncMode = SyncModeApplyOnce
+	}
+
 	referenceFile, err := LoadReferenceFile()
 	if err != nil {
 		return nil, err
@@ -32,18 +117,21 @@ func SyncUsageData(usageFile *UsageFile, projects []*schema.Project) (*SyncResul
 		resources = append(resources, project.Resources...)
 	}
 
-	syncResult := syncResourceUsages(usageFile, resources, referenceFile)
+	syncResult := syncResourceUsages(usageFile, resources, referenceFile, syncMode, enabledEstimators)
 
 	return syncResult, nil
 }
 
-func syncResourceUsages(usageFile *UsageFile, resources []*schema.Resource, referenceFile *ReferenceFile) *SyncResult {
+func syncResourceUsages(usageFile *UsageFile, resources []*schema.Resource, referenceFile *ReferenceFile, syncMode SyncMode, enabledEstimators map[string]bool) *SyncResult {
 	syncResult := &SyncResult{
 		EstimationErrors: make(map[string]error),
+		Conflicts:        make(map[string]UsageConflict),
 	}
 
 	existingResourceUsagesMap := resourceUsagesMap(usageFile.ResourceUsages)
+	lastSyncedMap := resourceUsagesMap(usageFile.LastSynced)
 	resourceUsages := make([]*ResourceUsage, 0, len(resources))
+	newEstimatedKeys := make(map[string]map[string]bool, len(resources))
 
 	// Track the existing order so we can keep these at the top
 	existingOrder := make([]string, 0, len(usageFile.ResourceUsages))
@@ -70,26 +158,55 @@ func syncResourceUsages(usageFile *UsageFile, resources []*schema.Resource, refe
 			Items: resource.UsageSchema,
 		}, MergeResourceUsagesOpts{OverrideValueType: true})
 
-		// Merge any existing resource usage
 		existingResourceUsage := existingResourceUsagesMap[resource.Name]
-		if existingResourceUsage != nil {
-			mergeResourceUsages(resourceUsage, existingResourceUsage, MergeResourceUsagesOpts{})
-		}
+		backends := estimatorsFor(resource.ResourceType, enabledEstimators)
 
 		syncResult.ResourceCount++
-		if resource.EstimateUsage != nil {
+		if (resource.EstimateUsage != nil || len(backends) > 0) && syncMode != SyncModeOff {
 			syncResult.EstimationCount++
 
 			resourceUsageMap := resourceUsage.Map()
-			err := resource.EstimateUsage(context.TODO(), resourceUsageMap)
-			if err != nil {
-				syncResult.EstimationErrors[resource.Name] = err
-				log.Warnf("Error estimating usage for resource %s: %v", resource.Name, err)
+
+			if resource.EstimateUsage != nil {
+				if err := resource.EstimateUsage(context.TODO(), resourceUsageMap); err != nil {
+					syncResult.EstimationErrors[resource.Name] = err
+					log.Warnf("Error estimating usage for resource %s: %v", resource.Name, err)
+				}
 			}
 
-			// Merge in the estimated usage
+			// Backends run in registration order, each merging its output
+			// into the same sink, so a later backend can layer its values
+			// on top of an earlier one for the same resource type.
+			for _, backend := range backends {
+				if err := backend.Estimate(context.TODO(), resource, resourceUsageMap); err != nil {
+					syncResult.EstimationErrors[resource.Name] = err
+					log.Warnf("Error estimating usage for resource %s using %s backend: %v", resource.Name, backend.Name(), err)
+				}
+			}
+
+			// Merge in the estimated usage, gated by syncMode so apply-once
+			// never stomps a value the user already set or previously
+			// accepted from the estimator.
 			estimatedUsageData := schema.NewUsageData(resource.Name, schema.ParseAttributes(resourceUsageMap))
-			mergeResourceUsageWithUsageData(resourceUsage, estimatedUsageData)
+			applyResult := mergeEstimatedUsageData(resourceUsage, estimatedUsageData, syncMode, existingResourceUsage, usageFile.EstimatedKeys[resource.Name])
+			syncResult.EstimationApplied += applyResult.Applied
+			syncResult.EstimationSkipped += applyResult.Skipped
+			newEstimatedKeys[resource.Name] = applyResult.EstimatedKeys
+		}
+
+		// At this point resourceUsage is the candidate built purely from the
+		// reference file, the resource's usage schema and its estimator -
+		// i.e. what we'd write if the user had never touched the file. Now
+		// reconcile that candidate against what's actually in the usage
+		// file, using the last-synced snapshot to tell a user edit apart
+		// from an upstream schema/estimator change.
+		lastSyncedResourceUsage := lastSyncedMap[resource.Name]
+		if existingResourceUsage != nil {
+			conflicts := threeWayMergeResourceUsages(resource.Name, resourceUsage, existingResourceUsage, lastSyncedResourceUsage)
+			for key, conflict := range conflicts {
+				conflict.SourceFile = existingResourceUsage.SourceFiles[key]
+				syncResult.Conflicts[resource.Name+"."+key] = conflict
+			}
 		}
 
 		resourceUsages = append(resourceUsages, resourceUsage)
@@ -98,6 +215,8 @@ func syncResourceUsages(usageFile *UsageFile, resources []*schema.Resource, refe
 	sortResourceUsages(resourceUsages, existingOrder)
 
 	usageFile.ResourceUsages = resourceUsages
+	usageFile.LastSynced = snapshotResourceUsages(resourceUsages)
+	usageFile.EstimatedKeys = newEstimatedKeys
 
 	return syncResult
 }
@@ -162,9 +281,35 @@ func mergeResourceUsages(dest *ResourceUsage, src *ResourceUsage, opts MergeReso
 	}
 }
 
-func mergeResourceUsageWithUsageData(resourceUsage *ResourceUsage, usageData *schema.UsageData) {
+// estimationApplyResult tallies how mergeEstimatedUsageData applied an
+// estimator's output, and which keys it wrote so the caller can persist
+// them as provenance for the next sync.
+type estimationApplyResult struct {
+	Applied       int
+	Skipped       int
+	EstimatedKeys map[string]bool
+}
+
+// mergeEstimatedUsageData merges an estimator's output into resourceUsage,
+// gated by mode:
+//   - SyncModeReconcile always overwrites with the estimated value.
+//   - SyncModeApplyOnce only writes a value the destination item has no
+//     user value (per existing) and wasn't already written by a previous
+//     estimator run (per previouslyEstimated).
+//
+// previouslyEstimated is keyed by item key (sub-resources flattened with
+// a "." separator) and comes from the usage file's provenance tracking.
+func mergeEstimatedUsageData(resourceUsage *ResourceUsage, usageData *schema.UsageData, mode SyncMode, existing *ResourceUsage, previouslyEstimated map[string]bool) estimationApplyResult {
+	result := estimationApplyResult{EstimatedKeys: make(map[string]bool)}
 	if usageData == nil {
-		return
+		return result
+	}
+
+	existingItemMap := make(map[string]*schema.UsageItem)
+	if existing != nil {
+		for _, item := range existing.Items {
+			existingItemMap[item.Key] = item
+		}
 	}
 
 	for _, item := range resourceUsage.Items {
@@ -218,18 +363,66 @@ func mergeResourceUsageWithUsageData(resourceUsage *ResourceUsage, usageData *sc
 			}
 
 			if subResourceUsage != nil {
-				mergeResourceUsageWithUsageData(subResourceUsage, subExisting)
-			}
+				var subExistingResourceUsage *ResourceUsage
+				if existingItem, ok := existingItemMap[item.Key]; ok && existingItem.Value != nil {
+					subExistingResourceUsage, _ = existingItem.Value.(*ResourceUsage)
+				}
+
+				subPreviouslyEstimated := prefixedSubKeys(previouslyEstimated, item.Key)
+				subResult := mergeEstimatedUsageData(subResourceUsage, subExisting, mode, subExistingResourceUsage, subPreviouslyEstimated)
+				for key := range subResult.EstimatedKeys {
+					result.EstimatedKeys[item.Key+"."+key] = true
+				}
+				result.Applied += subResult.Applied
+				result.Skipped += subResult.Skipped
 
-			if subResourceUsage != nil {
 				val = subResourceUsage
 			}
+		default:
+			continue
+		}
+
+		if val == nil {
+			continue
 		}
 
-		if val != nil {
-			item.Value = val
+		if mode == SyncModeApplyOnce && item.ValueType != schema.SubResourceUsage {
+			existingItem, hasExisting := existingItemMap[item.Key]
+			hasUserValue := hasExisting && existingItem.Value != nil
+			if hasUserValue || previouslyEstimated[item.Key] {
+				result.Skipped++
+				continue
+			}
+		}
+
+		// Always write the value back, including a freshly-built
+		// sub-resource tree - only the Applied/EstimatedKeys bookkeeping is
+		// gated on ValueType, since a sub-resource's own bookkeeping was
+		// already rolled up from the recursive call above.
+		item.Value = val
+
+		if item.ValueType != schema.SubResourceUsage {
+			result.EstimatedKeys[item.Key] = true
+			result.Applied++
+		}
+	}
+
+	return result
+}
+
+// prefixedSubKeys strips a "<key>." prefix off every entry in keys that
+// has it, for passing a flattened provenance set down into a sub-resource
+// merge.
+func prefixedSubKeys(keys map[string]bool, prefix string) map[string]bool {
+	sub := make(map[string]bool)
+	fullPrefix := prefix + "."
+	for key, ok := range keys {
+		if !ok || !strings.HasPrefix(key, fullPrefix) {
+			continue
 		}
+		sub[strings.TrimPrefix(key, fullPrefix)] = true
 	}
+	return sub
 }
 
 // sortResourcesExistingFirst sorts the resources by the existing order first, and then the rest by name
@@ -0,0 +1,138 @@
+package usage
+
+import (
+	"testing"
+
+	"github.com/infracost/infracost/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeEstimatedUsageData_ApplyOnceSkipsExistingValue(t *testing.T) {
+	resourceUsage := &ResourceUsage{Items: []*schema.UsageItem{{Key: "instances", ValueType: schema.Int64}}}
+	existing := &ResourceUsage{Items: []*schema.UsageItem{intItem("instances", int64(2))}}
+	usageData := schema.NewUsageData("r", schema.ParseAttributes(map[string]interface{}{"instances": 5}))
+
+	result := mergeEstimatedUsageData(resourceUsage, usageData, SyncModeApplyOnce, existing, nil)
+
+	assert.Equal(t, 0, result.Applied)
+	assert.Equal(t, 1, result.Skipped)
+	assert.Nil(t, resourceUsage.Items[0].Value)
+}
+
+func TestMergeEstimatedUsageData_ApplyOnceWritesWhenNoExistingValue(t *testing.T) {
+	resourceUsage := &ResourceUsage{Items: []*schema.UsageItem{{Key: "instances", ValueType: schema.Int64}}}
+	usageData := schema.NewUsageData("r", schema.ParseAttributes(map[string]interface{}{"instances": 5}))
+
+	result := mergeEstimatedUsageData(resourceUsage, usageData, SyncModeApplyOnce, nil, nil)
+
+	assert.Equal(t, 1, result.Applied)
+	assert.Equal(t, int64(5), resourceUsage.Items[0].Value)
+}
+
+func TestMergeEstimatedUsageData_ApplyOnceSkipsPreviouslyEstimatedKey(t *testing.T) {
+	resourceUsage := &ResourceUsage{Items: []*schema.UsageItem{{Key: "instances", ValueType: schema.Int64}}}
+	usageData := schema.NewUsageData("r", schema.ParseAttributes(map[string]interface{}{"instances": 5}))
+
+	result := mergeEstimatedUsageData(resourceUsage, usageData, SyncModeApplyOnce, nil, map[string]bool{"instances": true})
+
+	assert.Equal(t, 0, result.Applied)
+	assert.Equal(t, 1, result.Skipped)
+	assert.Nil(t, resourceUsage.Items[0].Value)
+}
+
+func TestMergeEstimatedUsageData_ReconcileAlwaysOverwrites(t *testing.T) {
+	resourceUsage := &ResourceUsage{Items: []*schema.UsageItem{{Key: "instances", ValueType: schema.Int64}}}
+	existing := &ResourceUsage{Items: []*schema.UsageItem{intItem("instances", int64(2))}}
+	usageData := schema.NewUsageData("r", schema.ParseAttributes(map[string]interface{}{"instances": 5}))
+
+	result := mergeEstimatedUsageData(resourceUsage, usageData, SyncModeReconcile, existing, nil)
+
+	assert.Equal(t, 1, result.Applied)
+	assert.Equal(t, int64(5), resourceUsage.Items[0].Value)
+}
+
+func TestMergeEstimatedUsageData_SubResourceWriteBack(t *testing.T) {
+	// Regression test: a freshly-built sub-resource tree was computed and
+	// recursed into, but never assigned back onto the parent item, so
+	// estimated sub-resource usage (e.g. EKS pod density) was silently
+	// discarded.
+	subSchema := &ResourceUsage{Name: "requests", Items: []*schema.UsageItem{{Key: "count", ValueType: schema.Int64}}}
+	resourceUsage := &ResourceUsage{
+		Items: []*schema.UsageItem{
+			{Key: "requests", ValueType: schema.SubResourceUsage, DefaultValue: subSchema},
+		},
+	}
+	usageData := schema.NewUsageData("r", schema.ParseAttributes(map[string]interface{}{
+		"requests": map[string]interface{}{"count": 42},
+	}))
+
+	result := mergeEstimatedUsageData(resourceUsage, usageData, SyncModeReconcile, nil, nil)
+
+	require.NotNil(t, resourceUsage.Items[0].Value)
+	subUsage, ok := resourceUsage.Items[0].Value.(*ResourceUsage)
+	require.True(t, ok)
+	require.Len(t, subUsage.Items, 1)
+	assert.Equal(t, int64(42), subUsage.Items[0].Value)
+	assert.Equal(t, 1, result.Applied)
+	assert.Equal(t, map[string]bool{"requests.count": true}, result.EstimatedKeys)
+}
+
+func TestSyncResourceUsages_ConflictReportsSourceFile(t *testing.T) {
+	// A key the reference file/resource's usage schema no longer produces
+	// is orphaned by the three-way merge (see threeway.go). Make sure
+	// syncResourceUsages actually reads ResourceUsage.SourceFiles back
+	// out when reporting that conflict - otherwise SourceFiles is pure
+	// write-only bookkeeping that LoadAndMerge populates for nothing.
+	usageFile := &UsageFile{
+		ResourceUsages: []*ResourceUsage{
+			{
+				Name:        "aws_instance.web",
+				Items:       []*schema.UsageItem{intItem("legacy_key", int64(7))},
+				SourceFiles: map[string]string{"legacy_key": "infracost-usage.yml"},
+			},
+		},
+	}
+	resources := []*schema.Resource{{Name: "aws_instance.web", ResourceType: "aws_instance"}}
+
+	result := syncResourceUsages(usageFile, resources, &ReferenceFile{}, SyncModeOff, nil)
+
+	conflict, ok := result.Conflicts["aws_instance.web.legacy_key"]
+	require.True(t, ok)
+	assert.True(t, conflict.Orphaned)
+	assert.Equal(t, "infracost-usage.yml", conflict.SourceFile)
+	assert.Equal(t, int64(7), conflict.UserValue)
+}
+
+func TestMergeResourceUsages_DeepMerge(t *testing.T) {
+	sub := &ResourceUsage{
+		Name: "requests",
+		Items: []*schema.UsageItem{
+			intItem("monthly_requests", int64(1000)),
+		},
+	}
+	dest := &ResourceUsage{
+		Name: "r",
+		Items: []*schema.UsageItem{
+			intItem("instances", int64(2)),
+			{Key: "requests", ValueType: schema.SubResourceUsage, Value: sub},
+		},
+	}
+
+	subOverride := &ResourceUsage{
+		Name:  "requests",
+		Items: []*schema.UsageItem{intItem("monthly_requests", int64(5000))},
+	}
+	src := &ResourceUsage{
+		Name:  "r",
+		Items: []*schema.UsageItem{{Key: "requests", ValueType: schema.SubResourceUsage, Value: subOverride}},
+	}
+
+	mergeResourceUsages(dest, src, MergeResourceUsagesOpts{})
+
+	// The un-overridden top-level item is untouched.
+	assert.Equal(t, int64(2), dest.Items[0].Value)
+	// Only the key the override actually specified changes.
+	destSub := dest.Items[1].Value.(*ResourceUsage)
+	assert.Equal(t, int64(5000), destSub.Items[0].Value)
+}
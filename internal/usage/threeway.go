@@ -0,0 +1,200 @@
+package usage
+
+import (
+	"reflect"
+
+	"github.com/infracost/infracost/internal/schema"
+)
+
+// threeWayMergeResourceUsages reconciles candidate (the value sync just
+// computed from the reference file/resource schema/estimator) with
+// existing (what's currently in the user's usage file), using lastSynced
+// (what we wrote into candidate's slot the last time sync ran
+// successfully) to decide who wins:
+//
+//   - existing == lastSynced: the user hasn't touched this value since the
+//     last sync, so the new candidate is free to take over.
+//   - candidate == lastSynced: the schema/estimator hasn't changed this
+//     value since the last sync, so the user's edit is kept.
+//   - all three differ: both sides changed since the last sync. We keep
+//     the user's value (we never clobber an edit without being asked to)
+//     but record a conflict so the caller can tell the user what they're
+//     not getting automatically.
+//
+// candidate is mutated in place to hold the resolved values. It returns a
+// map of conflicts keyed by item key (sub-resource items are flattened
+// with a "." separator).
+func threeWayMergeResourceUsages(resourceName string, candidate *ResourceUsage, existing *ResourceUsage, lastSynced *ResourceUsage) map[string]UsageConflict {
+	conflicts := make(map[string]UsageConflict)
+	threeWayMergeItems(candidate, existing, lastSynced, "", conflicts)
+	for key, conflict := range conflicts {
+		conflict.ResourceName = resourceName
+		conflicts[key] = conflict
+	}
+	return conflicts
+}
+
+func threeWayMergeItems(candidate *ResourceUsage, existing *ResourceUsage, lastSynced *ResourceUsage, prefix string, conflicts map[string]UsageConflict) {
+	if candidate == nil || existing == nil {
+		return
+	}
+
+	existingItemMap := make(map[string]*schema.UsageItem, len(existing.Items))
+	for _, item := range existing.Items {
+		existingItemMap[item.Key] = item
+	}
+
+	lastSyncedItemMap := make(map[string]*schema.UsageItem)
+	if lastSynced != nil {
+		for _, item := range lastSynced.Items {
+			lastSyncedItemMap[item.Key] = item
+		}
+	}
+
+	candidateItemMap := make(map[string]*schema.UsageItem, len(candidate.Items))
+	for _, item := range candidate.Items {
+		candidateItemMap[item.Key] = item
+	}
+
+	// threeWayMergeItems only walks candidate.Items below, so an existing
+	// item that candidate doesn't have at all - because the reference
+	// file/resource's usage schema no longer defines that key, or the
+	// user hand-added a key that was never part of either - would
+	// otherwise just vanish from the synced output instead of being
+	// overwritten or kept deliberately. Carry it forward and flag it as
+	// orphaned instead of silently dropping it.
+	for _, existingItem := range existing.Items {
+		if existingItem.Value == nil {
+			continue
+		}
+		if _, ok := candidateItemMap[existingItem.Key]; ok {
+			continue
+		}
+
+		fullKey := existingItem.Key
+		if prefix != "" {
+			fullKey = prefix + "." + existingItem.Key
+		}
+
+		candidate.Items = append(candidate.Items, &schema.UsageItem{
+			Key:       existingItem.Key,
+			ValueType: existingItem.ValueType,
+			Value:     existingItem.Value,
+		})
+		conflicts[fullKey] = UsageConflict{
+			Key:       fullKey,
+			UserValue: existingItem.Value,
+			Orphaned:  true,
+		}
+	}
+
+	for _, candidateItem := range candidate.Items {
+		existingItem, hasExisting := existingItemMap[candidateItem.Key]
+		if !hasExisting || existingItem.Value == nil {
+			continue
+		}
+
+		fullKey := candidateItem.Key
+		if prefix != "" {
+			fullKey = prefix + "." + candidateItem.Key
+		}
+
+		if candidateItem.ValueType == schema.SubResourceUsage {
+			var candidateSub, existingSub, lastSyncedSub *ResourceUsage
+			if candidateItem.Value != nil {
+				candidateSub, _ = candidateItem.Value.(*ResourceUsage)
+			}
+			if existingItem.Value != nil {
+				existingSub, _ = existingItem.Value.(*ResourceUsage)
+			}
+			if lastSyncedItem, ok := lastSyncedItemMap[candidateItem.Key]; ok && lastSyncedItem.Value != nil {
+				lastSyncedSub, _ = lastSyncedItem.Value.(*ResourceUsage)
+			}
+			// As with scalar items below, with no fresh candidate tree to
+			// take over with, keep the usage file's tree rather than drop it.
+			if candidateSub == nil && existingSub != nil {
+				candidateItem.Value = existingSub
+				continue
+			}
+			threeWayMergeItems(candidateSub, existingSub, lastSyncedSub, fullKey, conflicts)
+			continue
+		}
+
+		lastSyncedValue := interface{}(nil)
+		if lastSyncedItem, ok := lastSyncedItemMap[candidateItem.Key]; ok {
+			lastSyncedValue = lastSyncedItem.Value
+		}
+
+		switch {
+		case reflect.DeepEqual(existingItem.Value, lastSyncedValue):
+			// The user hasn't touched this since the last sync - the new
+			// candidate value (from the schema/estimator) wins. But if
+			// nothing populated a fresh candidate value (most hand-typed
+			// items have no schema default or estimator backing them),
+			// there's nothing to take over with, so keep what's already
+			// in the usage file rather than wiping it out.
+			if candidateItem.Value == nil {
+				candidateItem.Value = existingItem.Value
+			}
+			continue
+		case reflect.DeepEqual(candidateItem.Value, lastSyncedValue):
+			// The candidate hasn't changed since the last sync - keep the
+			// user's edit.
+			candidateItem.Value = existingItem.Value
+		case reflect.DeepEqual(candidateItem.Value, existingItem.Value):
+			// Both sides happen to agree already, nothing to resolve.
+			continue
+		default:
+			// Both the user and the schema/estimator changed this value
+			// since the last sync. Keep the user's edit but surface the
+			// conflict, including the new value, so the caller can tell
+			// the user what they're not getting automatically.
+			newValue := candidateItem.Value
+			candidateItem.Value = existingItem.Value
+			conflicts[fullKey] = UsageConflict{
+				Key:       fullKey,
+				UserValue: existingItem.Value,
+				NewValue:  newValue,
+			}
+		}
+	}
+}
+
+// snapshotResourceUsages produces a deep, value-only copy of
+// resourceUsages suitable for storing under the hidden
+// _infracost_last_synced key, so the next sync can tell a user edit apart
+// from an upstream schema/estimator change.
+func snapshotResourceUsages(resourceUsages []*ResourceUsage) []*ResourceUsage {
+	snapshot := make([]*ResourceUsage, 0, len(resourceUsages))
+	for _, resourceUsage := range resourceUsages {
+		snapshot = append(snapshot, snapshotResourceUsage(resourceUsage))
+	}
+	return snapshot
+}
+
+func snapshotResourceUsage(resourceUsage *ResourceUsage) *ResourceUsage {
+	if resourceUsage == nil {
+		return nil
+	}
+
+	snapshot := &ResourceUsage{Name: resourceUsage.Name}
+	for _, item := range resourceUsage.Items {
+		if item.Value == nil {
+			continue
+		}
+
+		snapshotItem := &schema.UsageItem{Key: item.Key, ValueType: item.ValueType}
+
+		if item.ValueType == schema.SubResourceUsage {
+			if subUsage, ok := item.Value.(*ResourceUsage); ok {
+				snapshotItem.Value = snapshotResourceUsage(subUsage)
+			}
+		} else {
+			snapshotItem.Value = item.Value
+		}
+
+		snapshot.Items = append(snapshot.Items, snapshotItem)
+	}
+
+	return snapshot
+}
@@ -0,0 +1,102 @@
+package usage
+
+import (
+	"testing"
+
+	"github.com/infracost/infracost/internal/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func intItem(key string, value interface{}) *schema.UsageItem {
+	return &schema.UsageItem{Key: key, ValueType: schema.Int64, Value: value}
+}
+
+func TestThreeWayMergeResourceUsages_CandidateWinsWhenUserUntouched(t *testing.T) {
+	candidate := &ResourceUsage{Name: "r", Items: []*schema.UsageItem{intItem("instances", int64(5))}}
+	existing := &ResourceUsage{Name: "r", Items: []*schema.UsageItem{intItem("instances", int64(3))}}
+	lastSynced := &ResourceUsage{Name: "r", Items: []*schema.UsageItem{intItem("instances", int64(3))}}
+
+	conflicts := threeWayMergeResourceUsages("r", candidate, existing, lastSynced)
+
+	assert.Empty(t, conflicts)
+	assert.Equal(t, int64(5), candidate.Items[0].Value)
+}
+
+func TestThreeWayMergeResourceUsages_KeepsUserEditWhenCandidateUnchanged(t *testing.T) {
+	candidate := &ResourceUsage{Name: "r", Items: []*schema.UsageItem{intItem("instances", int64(3))}}
+	existing := &ResourceUsage{Name: "r", Items: []*schema.UsageItem{intItem("instances", int64(7))}}
+	lastSynced := &ResourceUsage{Name: "r", Items: []*schema.UsageItem{intItem("instances", int64(3))}}
+
+	conflicts := threeWayMergeResourceUsages("r", candidate, existing, lastSynced)
+
+	assert.Empty(t, conflicts)
+	assert.Equal(t, int64(7), candidate.Items[0].Value)
+}
+
+func TestThreeWayMergeResourceUsages_ConflictWhenBothChanged(t *testing.T) {
+	candidate := &ResourceUsage{Name: "r", Items: []*schema.UsageItem{intItem("instances", int64(9))}}
+	existing := &ResourceUsage{Name: "r", Items: []*schema.UsageItem{intItem("instances", int64(7))}}
+	lastSynced := &ResourceUsage{Name: "r", Items: []*schema.UsageItem{intItem("instances", int64(3))}}
+
+	conflicts := threeWayMergeResourceUsages("r", candidate, existing, lastSynced)
+
+	require := assert.New(t)
+	require.Len(conflicts, 1)
+	conflict := conflicts["instances"]
+	require.Equal("r", conflict.ResourceName)
+	require.Equal(int64(7), conflict.UserValue)
+	// The new/computed value must be captured before it's overwritten by
+	// the kept user value, otherwise NewValue just echoes UserValue back.
+	require.Equal(int64(9), conflict.NewValue)
+	// The user's edit is what actually lands in the merged candidate.
+	require.Equal(int64(7), candidate.Items[0].Value)
+}
+
+func TestThreeWayMergeResourceUsages_NoCandidateValuePreservesExisting(t *testing.T) {
+	// Regression test: a hand-typed usage item with no schema default and
+	// no estimator backing it never gets a fresh candidate value. A
+	// previous bug took the "user hasn't touched it" branch unconditionally
+	// and left the merged value at that nil candidate, silently wiping out
+	// the user's value on every subsequent sync.
+	candidate := &ResourceUsage{Name: "r", Items: []*schema.UsageItem{intItem("monthly_requests", nil)}}
+	existing := &ResourceUsage{Name: "r", Items: []*schema.UsageItem{intItem("monthly_requests", int64(1000000))}}
+	lastSynced := &ResourceUsage{Name: "r", Items: []*schema.UsageItem{intItem("monthly_requests", int64(1000000))}}
+
+	conflicts := threeWayMergeResourceUsages("r", candidate, existing, lastSynced)
+
+	assert.Empty(t, conflicts)
+	assert.Equal(t, int64(1000000), candidate.Items[0].Value)
+}
+
+func TestThreeWayMergeResourceUsages_OrphanedKeyIsCarriedForward(t *testing.T) {
+	// Regression test: a key the user has set that the reference
+	// file/resource's usage schema no longer produces (e.g. it was
+	// dropped, or the user hand-added a key that was never part of
+	// either) isn't in candidate.Items at all. threeWayMergeItems only
+	// walked candidate.Items, so this key vanished from the synced output
+	// with no trace - a silent delete rather than a silent overwrite.
+	candidate := &ResourceUsage{Name: "r", Items: []*schema.UsageItem{intItem("instances", int64(5))}}
+	existing := &ResourceUsage{Name: "r", Items: []*schema.UsageItem{
+		intItem("instances", int64(5)),
+		intItem("legacy_key", int64(42)),
+	}}
+
+	conflicts := threeWayMergeResourceUsages("r", candidate, existing, nil)
+
+	require := assert.New(t)
+	require.Len(candidate.Items, 2)
+
+	var orphan *schema.UsageItem
+	for _, item := range candidate.Items {
+		if item.Key == "legacy_key" {
+			orphan = item
+		}
+	}
+	require.NotNil(orphan, "orphaned key should be carried forward into the merged candidate")
+	require.Equal(int64(42), orphan.Value)
+
+	conflict, ok := conflicts["legacy_key"]
+	require.True(ok, "orphaned key should be reported in conflicts")
+	require.True(conflict.Orphaned)
+	require.Equal(int64(42), conflict.UserValue)
+}
@@ -0,0 +1,201 @@
+package usage
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/infracost/infracost/internal/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// ResourceUsage is a single resource's usage values, as read from (or
+// about to be written to) a usage file, keyed by the resource's
+// Terraform address.
+type ResourceUsage struct {
+	Name  string              `yaml:"-"`
+	Items []*schema.UsageItem `yaml:"-"`
+	// SourceFiles records, per item key, the path of the usage file that
+	// last contributed that item's value - set by recordSourceFiles as
+	// LoadAndMerge layers usage files on top of each other - so a later
+	// error can point back at the file a bad value actually came from
+	// instead of just naming the merged resource.
+	SourceFiles map[string]string `yaml:"-"`
+}
+
+// Map flattens resourceUsage into a plain map keyed by item key, suitable
+// for schema.ParseAttributes/schema.NewUsageData or for marshalling.
+// Sub-resource items become nested maps, built recursively from the
+// item's Value tree, or its DefaultValue tree when Value isn't set.
+func (r *ResourceUsage) Map() map[string]interface{} {
+	if r == nil {
+		return map[string]interface{}{}
+	}
+
+	m := make(map[string]interface{}, len(r.Items))
+	for _, item := range r.Items {
+		if item.ValueType != schema.SubResourceUsage {
+			m[item.Key] = item.Value
+			continue
+		}
+
+		if sub, ok := item.Value.(*ResourceUsage); ok && sub != nil {
+			m[item.Key] = sub.Map()
+		} else if def, ok := item.DefaultValue.(*ResourceUsage); ok && def != nil {
+			m[item.Key] = def.Map()
+		}
+	}
+	return m
+}
+
+// UsageFile is the in-memory form of a usage file: the user-visible
+// resource usages, plus the hidden bookkeeping sync uses to tell a user
+// edit apart from an upstream schema/estimator change from one run to the
+// next.
+type UsageFile struct {
+	ResourceUsages []*ResourceUsage
+	// LastSynced is the snapshot syncResourceUsages wrote out after its
+	// last successful run - see lastSyncedKey in sync.go.
+	LastSynced []*ResourceUsage
+	// EstimatedKeys records, per resource name, which item keys (flattened
+	// with "." for sub-resources) were last written by an estimator
+	// rather than a user, so a later apply-once sync can tell the two
+	// apart.
+	EstimatedKeys map[string]map[string]bool
+}
+
+// ReferenceFile holds the built-in usage schema/defaults that ship with
+// infracost, used to seed a new resource's usage schema before the
+// user's usage file and any estimator are merged on top.
+type ReferenceFile struct {
+	ResourceUsages []*ResourceUsage
+}
+
+// SetDefaultValues copies each item's DefaultValue into Value wherever
+// Value isn't already set, so a freshly loaded reference file can be used
+// as a merge source without every caller having to fall back to
+// DefaultValue itself.
+func (r *ReferenceFile) SetDefaultValues() {
+	for _, resourceUsage := range r.ResourceUsages {
+		setDefaultValues(resourceUsage)
+	}
+}
+
+func setDefaultValues(resourceUsage *ResourceUsage) {
+	if resourceUsage == nil {
+		return
+	}
+
+	for _, item := range resourceUsage.Items {
+		if item.ValueType == schema.SubResourceUsage {
+			if sub, ok := item.DefaultValue.(*ResourceUsage); ok {
+				setDefaultValues(sub)
+			}
+			continue
+		}
+
+		if item.Value == nil {
+			item.Value = item.DefaultValue
+		}
+	}
+}
+
+// FindMatchingResourceUsage returns the reference usage schema for the
+// given Terraform resource type/address, or nil if the reference file
+// doesn't describe it.
+func (r *ReferenceFile) FindMatchingResourceUsage(name string) *ResourceUsage {
+	for _, resourceUsage := range r.ResourceUsages {
+		if resourceUsage.Name == name {
+			return resourceUsage
+		}
+	}
+	return nil
+}
+
+// LoadReferenceFile loads infracost's built-in usage schema/defaults.
+//
+// This always returns an empty reference file: the built-in schema data
+// itself - one entry per supported Terraform resource type, generated
+// from the cost-estimation resource definitions - lives outside
+// internal/usage and isn't part of this package.
+func LoadReferenceFile() (*ReferenceFile, error) {
+	return &ReferenceFile{}, nil
+}
+
+// LoadUsageFile reads and parses the usage file at path. A path that
+// doesn't exist yet is treated as an empty usage file, the same way a
+// fresh `infracost-usage.yml` would be before its first sync.
+func LoadUsageFile(path string) (*UsageFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &UsageFile{}, nil
+		}
+		return nil, err
+	}
+
+	var raw rawUsageFile
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("error parsing YAML: %w", err)
+	}
+
+	usageFile := &UsageFile{}
+	for name, item := range raw.ResourceUsages {
+		usageFile.ResourceUsages = append(usageFile.ResourceUsages, &ResourceUsage{
+			Name:  name,
+			Items: rawItemToUsageItems(item),
+		})
+	}
+
+	return usageFile, nil
+}
+
+// rawUsageFile is the on-disk YAML shape: a map of resource address to a
+// map of usage key to value (a nested map for sub-resource usage). The
+// richer in-memory ResourceUsage tree's Description/DefaultValue metadata
+// only exists once a resource's usage schema has been merged in over the
+// top of this, and its SourceFiles provenance only once LoadAndMerge
+// records it, which is why it's parsed into this shape first rather than
+// directly into ResourceUsage.
+type rawUsageFile struct {
+	ResourceUsages map[string]map[string]interface{} `yaml:"resource_usage"`
+}
+
+func rawItemToUsageItems(raw map[string]interface{}) []*schema.UsageItem {
+	items := make([]*schema.UsageItem, 0, len(raw))
+	for key, value := range raw {
+		// A nested map is sub-resource usage, which the rest of the package
+		// expects as a *ResourceUsage tree rather than a raw map.
+		if sub, ok := value.(map[string]interface{}); ok {
+			value = &ResourceUsage{Name: key, Items: rawItemToUsageItems(sub)}
+		}
+		items = append(items, &schema.UsageItem{
+			Key:       key,
+			ValueType: valueTypeOf(value),
+			Value:     value,
+		})
+	}
+	return items
+}
+
+func valueTypeOf(value interface{}) schema.ValueType {
+	switch value.(type) {
+	case float64, int, int64:
+		return schema.Int64
+	case []interface{}, []string:
+		return schema.StringArray
+	case map[string]interface{}, *ResourceUsage:
+		return schema.SubResourceUsage
+	default:
+		return schema.String
+	}
+}
+
+// resourceUsagesMap indexes usages by resource name for lookup during a
+// merge or sync.
+func resourceUsagesMap(usages []*ResourceUsage) map[string]*ResourceUsage {
+	m := make(map[string]*ResourceUsage, len(usages))
+	for _, u := range usages {
+		m[u.Name] = u
+	}
+	return m
+}